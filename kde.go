@@ -0,0 +1,184 @@
+package heatmap
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Compression controls how an accumulated density buffer is compressed
+// before being normalized and mapped through a color scheme. Raw
+// densities are heavily right-skewed by a handful of hot spots, so a
+// compressive transform is usually what makes the rest of the data
+// visible.
+type Compression int
+
+const (
+	// CompressionNone normalizes the raw density by its maximum.
+	CompressionNone Compression = iota
+	// CompressionSqrt takes the square root of each density before
+	// normalizing.
+	CompressionSqrt
+	// CompressionLog takes log(1+x) of each density before
+	// normalizing.
+	CompressionLog
+)
+
+func (c Compression) apply(v float32) float32 {
+	switch c {
+	case CompressionSqrt:
+		return float32(math.Sqrt(float64(v)))
+	case CompressionLog:
+		return float32(math.Log1p(float64(v)))
+	default:
+		return v
+	}
+}
+
+// Accumulate rasterizes points into a float32 density buffer the size of
+// size, row-major, by summing each point's Kernel contribution (scaled
+// by its weight, if it implements WeightedDataPoint) into every pixel
+// within the kernel's radius. Points are expected to already be in pixel
+// coordinates relative to size. It is the shared accumulation step
+// behind HeatmapKDE, and is exported so other packages (such as
+// heatmap/tiles) can build on the same kernel-density math.
+func Accumulate(size image.Rectangle, points []DataPoint, kernel Kernel) []float32 {
+	w, h := size.Dx(), size.Dy()
+	density := make([]float32, w*h)
+	radius := kernel.Radius()
+
+	for _, p := range points {
+		weight := weightOf(p)
+		center := image.Point{
+			X: int(math.Floor(p.X())) - size.Min.X,
+			Y: int(math.Floor(p.Y())) - size.Min.Y,
+		}
+
+		minX, maxX := center.X-radius, center.X+radius
+		minY, maxY := center.Y-radius, center.Y+radius
+		if minX < 0 {
+			minX = 0
+		}
+		if minY < 0 {
+			minY = 0
+		}
+		if maxX >= w {
+			maxX = w - 1
+		}
+		if maxY >= h {
+			maxY = h - 1
+		}
+
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				dx, dy := float64(x-center.X), float64(y-center.Y)
+				density[y*w+x] += float32(weight * kernel.Weight(dx, dy))
+			}
+		}
+	}
+
+	return density
+}
+
+// HeatmapKDE draws a heatmap by accumulating each point's Kernel
+// contribution into a float32 density buffer, rather than stacking
+// alpha-blended dots. This makes it a proper kernel density estimate: a
+// single point with a large Weight outweighs many low-weight points
+// instead of just overdrawing them.
+//
+// size is the size of the image to create.
+// points are the samples to accumulate; implementing WeightedDataPoint
+// gives a point more or less influence than the default weight of 1.
+// kernel controls how each point's weight spreads to nearby pixels.
+// compression is applied to the raw density before it is normalized by
+// its maximum and mapped through scheme.
+// opacity is the alpha value (0-255) of the impact of the image overlay.
+// scheme is the color palette to choose from for the overlay.
+// proj projects points into pixel coordinates before they are
+// accumulated; a nil proj fits points to size using FitProjection.
+func HeatmapKDE(size image.Rectangle, points []DataPoint, kernel Kernel,
+	compression Compression, opacity uint8, scheme []color.Color, proj Projection) image.Image {
+
+	projected := projectPoints(points, size, proj)
+	density := Accumulate(size, projected, kernel)
+
+	return Colorize(density, size, compression, opacity, scheme)
+}
+
+// CompressDensity applies compression to every value of density in
+// place, returning it along with the maximum compressed value. Callers
+// that need to colorize several density buffers against a shared scale
+// (see ColorizeMax) compress each one first and take the maximum across
+// all of them before colorizing any.
+func CompressDensity(density []float32, compression Compression) (compressed []float32, max float32) {
+	for i, v := range density {
+		v = compression.apply(v)
+		density[i] = v
+		if v > max {
+			max = v
+		}
+	}
+	return density, max
+}
+
+// Colorize compresses and normalizes a density buffer (as produced by
+// Accumulate) by its own maximum, then maps it through scheme the same
+// way HeatmapKDE does. It's exported so other packages that accumulate
+// density themselves, such as heatmap/tiles, can reuse the same
+// compression and color mapping.
+func Colorize(density []float32, size image.Rectangle, compression Compression,
+	opacity uint8, scheme []color.Color) *image.RGBA {
+
+	compressed, max := CompressDensity(density, compression)
+	return ColorizeMax(compressed, size, max, opacity, scheme)
+}
+
+// ColorizeMax is like Colorize, but takes an already-compressed density
+// buffer (see CompressDensity) and an explicit maxDensity instead of
+// recomputing the maximum from density itself. This lets callers
+// normalize several density buffers against one shared maximum instead
+// of each other's own, which is what keeps heatmap/tiles' seams
+// continuous across tile boundaries.
+func ColorizeMax(density []float32, size image.Rectangle, maxDensity float32,
+	opacity uint8, scheme []color.Color) *image.RGBA {
+
+	rv := image.NewRGBA(size)
+	if maxDensity == 0 {
+		return rv
+	}
+
+	w := size.Dx()
+	for y := size.Min.Y; y < size.Max.Y; y++ {
+		for x := size.Min.X; x < size.Max.X; x++ {
+			percent := density[(y-size.Min.Y)*w+(x-size.Min.X)] / maxDensity
+			rv.Set(x, y, shade(percent, opacity, scheme))
+		}
+	}
+
+	return rv
+}
+
+// shade maps a normalized density (0-1) through scheme, scaling the
+// result's alpha by opacity. Unlike warm's dot-based Heatmap path, zero
+// density is fully transparent rather than a translucent grey wash, so
+// a KDE or tile overlay with no data at a pixel lets whatever is
+// underneath (e.g. a slippy map's basemap tiles) show through cleanly.
+func shade(percent float32, opacity uint8, scheme []color.Color) color.Color {
+	if percent <= 0 {
+		return color.Transparent
+	}
+	if percent > 1 {
+		percent = 1
+	}
+	collen := float64(len(scheme))
+	template := scheme[int((collen-1)*(1.0-float64(percent)))]
+	tr, tg, tb, ta := template.RGBA()
+	ta /= 256
+	outalpha := uint8(float64(ta) * (float64(opacity) / 256.0))
+	return color.NRGBA{
+		uint8(tr / 256),
+		uint8(tg / 256),
+		uint8(tb / 256),
+		outalpha,
+	}
+}
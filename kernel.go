@@ -0,0 +1,110 @@
+package heatmap
+
+import "math"
+
+// A WeightedDataPoint is a DataPoint that additionally carries a weight,
+// letting a single heavily-weighted event outweigh many light ones when
+// accumulated by a Kernel. Heatmap and HeatmapKDE detect this via a type
+// assertion, so plain DataPoints keep working unmodified with an implied
+// weight of 1.
+type WeightedDataPoint interface {
+	DataPoint
+	Weight() float64
+}
+
+func weightOf(p DataPoint) float64 {
+	if w, ok := p.(WeightedDataPoint); ok {
+		return w.Weight()
+	}
+	return 1
+}
+
+// A Kernel describes how a single data point spreads its weight across
+// nearby pixels. Radius gives the half-width, in pixels, beyond which the
+// kernel is considered to contribute nothing, and Weight gives the
+// contribution at an offset (dx, dy) from the point's center.
+type Kernel interface {
+	Radius() int
+	Weight(dx, dy float64) float64
+}
+
+// linearKernel is a cone-shaped falloff: weight decreases linearly with
+// distance from the center out to the radius.
+type linearKernel struct {
+	radius int
+}
+
+// LinearKernel builds the kernel Heatmap uses for its dots: a cone that
+// falls off linearly from 1 at the center to 0 at size/2 pixels out.
+func LinearKernel(size int) Kernel {
+	return linearKernel{radius: size / 2}
+}
+
+func (k linearKernel) Radius() int {
+	return k.radius
+}
+
+func (k linearKernel) Weight(dx, dy float64) float64 {
+	r := float64(k.radius)
+	if r <= 0 {
+		return 0
+	}
+	d := math.Sqrt(dx*dx + dy*dy)
+	if d >= r {
+		return 0
+	}
+	return 1 - d/r
+}
+
+// gaussianKernel is a true 2-D Gaussian, the standard kernel for kernel
+// density estimation.
+type gaussianKernel struct {
+	sigma  float64
+	radius int
+}
+
+// GaussianKernel builds a 2-D Gaussian kernel with the given bandwidth
+// sigma, in pixels. Its effective radius is auto-sized to ceil(3*sigma),
+// beyond which the Gaussian's contribution is negligible.
+func GaussianKernel(sigma float64) Kernel {
+	return gaussianKernel{
+		sigma:  sigma,
+		radius: int(math.Ceil(3 * sigma)),
+	}
+}
+
+func (k gaussianKernel) Radius() int {
+	return k.radius
+}
+
+func (k gaussianKernel) Weight(dx, dy float64) float64 {
+	return math.Exp(-(dx*dx + dy*dy) / (2 * k.sigma * k.sigma))
+}
+
+// epanechnikovKernel is the Epanechnikov quadratic kernel, a common
+// alternative to the Gaussian that is cheaper to evaluate and has
+// compact support.
+type epanechnikovKernel struct {
+	h float64
+}
+
+// EpanechnikovKernel builds an Epanechnikov kernel with bandwidth h, in
+// pixels: max(0, 1 - (r/h)^2).
+func EpanechnikovKernel(h float64) Kernel {
+	return epanechnikovKernel{h: h}
+}
+
+func (k epanechnikovKernel) Radius() int {
+	return int(math.Ceil(k.h))
+}
+
+func (k epanechnikovKernel) Weight(dx, dy float64) float64 {
+	if k.h <= 0 {
+		return 0
+	}
+	r := math.Sqrt(dx*dx+dy*dy) / k.h
+	if r >= 1 {
+		return 0
+	}
+	return 1 - r*r
+}
@@ -0,0 +1,158 @@
+package heatmap
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/math/f64"
+)
+
+// Limits describes the bounding box of a set of DataPoints in data
+// space, as computed by FindLimits.
+type Limits struct {
+	Min DataPoint
+	Max DataPoint
+}
+
+func (l Limits) Dx() float64 {
+	return l.Max.X() - l.Min.X()
+}
+
+func (l Limits) Dy() float64 {
+	return l.Max.Y() - l.Min.Y()
+}
+
+// FindLimits computes the bounding box, in data space, of points.
+func FindLimits(points []DataPoint) Limits {
+	minx, miny := points[0].X(), points[0].Y()
+	maxx, maxy := minx, miny
+
+	for _, p := range points {
+		minx = math.Min(p.X(), minx)
+		miny = math.Min(p.Y(), miny)
+		maxx = math.Max(p.X(), maxx)
+		maxy = math.Max(p.Y(), maxy)
+	}
+
+	return Limits{apoint{minx, miny}, apoint{maxx, maxy}}
+}
+
+// A Projection maps a DataPoint's data-space coordinates to a pixel
+// coordinate in the destination image. Heatmap and HeatmapKDE apply a
+// Projection exactly once per point, before placing a dot or
+// accumulating kernel weight, so arbitrary data coordinates (lat/lon,
+// timestamps, whatever a caller's DataPoint carries) land on-canvas
+// instead of being cast to pixels verbatim.
+type Projection interface {
+	Project(p DataPoint) image.Point
+}
+
+// affineProjection implements Projection with a 2-D affine transform,
+// the same representation golang.org/x/image/draw uses internally for
+// scale, rotation and skew.
+type affineProjection struct {
+	m f64.Aff3
+}
+
+// AffineProjection builds a Projection from an arbitrary affine
+// transform, for callers who need rotation or skew in addition to
+// scale and translation.
+func AffineProjection(m f64.Aff3) Projection {
+	return affineProjection{m}
+}
+
+func (a affineProjection) Project(p DataPoint) image.Point {
+	x, y := p.X(), p.Y()
+	return image.Point{
+		X: int(math.Round(a.m[0]*x + a.m[1]*y + a.m[2])),
+		Y: int(math.Round(a.m[3]*x + a.m[4]*y + a.m[5])),
+	}
+}
+
+// FitProjection builds a Projection that linearly scales and translates
+// limits to fit within dst, leaving padding pixels of border on every
+// side. If keepAspect is true the same scale factor is used on both
+// axes (the smaller of the two fits, so the result may not fill dst);
+// otherwise each axis is scaled independently to fill it.
+func FitProjection(limits Limits, dst image.Rectangle, padding int, keepAspect bool) Projection {
+	avail := image.Rect(
+		dst.Min.X+padding, dst.Min.Y+padding,
+		dst.Max.X-padding, dst.Max.Y-padding)
+
+	dx, dy := limits.Dx(), limits.Dy()
+	if dx == 0 {
+		dx = 1
+	}
+	if dy == 0 {
+		dy = 1
+	}
+
+	sx := float64(avail.Dx()-1) / dx
+	sy := float64(avail.Dy()-1) / dy
+	if keepAspect {
+		if sx < sy {
+			sy = sx
+		} else {
+			sx = sy
+		}
+	}
+
+	return AffineProjection(f64.Aff3{
+		sx, 0, float64(avail.Min.X) - sx*limits.Min.X(),
+		0, sy, float64(avail.Min.Y) - sy*limits.Min.Y(),
+	})
+}
+
+// LogProjection is like FitProjection, but scales both axes
+// logarithmically before fitting them to dst. It's suited to data that
+// spans several orders of magnitude on one or both axes.
+func LogProjection(limits Limits, dst image.Rectangle, padding int) Projection {
+	logLimits := Limits{
+		Min: apoint{math.Log(limits.Min.X()), math.Log(limits.Min.Y())},
+		Max: apoint{math.Log(limits.Max.X()), math.Log(limits.Max.Y())},
+	}
+	return logProjection{FitProjection(logLimits, dst, padding, false)}
+}
+
+type logProjection struct {
+	inner Projection
+}
+
+func (l logProjection) Project(p DataPoint) image.Point {
+	return l.inner.Project(apoint{math.Log(p.X()), math.Log(p.Y())})
+}
+
+// project applies proj to p and returns the result as a pixel-space
+// DataPoint, carrying p's weight through if it implements
+// WeightedDataPoint.
+func project(p DataPoint, proj Projection) DataPoint {
+	pt := proj.Project(p)
+	base := apoint{float64(pt.X), float64(pt.Y)}
+	if w, ok := p.(WeightedDataPoint); ok {
+		return weightedPoint{base, w.Weight()}
+	}
+	return base
+}
+
+type weightedPoint struct {
+	apoint
+	weight float64
+}
+
+func (w weightedPoint) Weight() float64 {
+	return w.weight
+}
+
+// projectPoints projects every point in points through proj, defaulting
+// proj to a fit-to-bounds Projection over points' own limits when proj
+// is nil.
+func projectPoints(points []DataPoint, size image.Rectangle, proj Projection) []DataPoint {
+	if proj == nil {
+		proj = FitProjection(FindLimits(points), size, 0, false)
+	}
+	rv := make([]DataPoint, len(points))
+	for i, p := range points {
+		rv[i] = project(p, proj)
+	}
+	return rv
+}
@@ -0,0 +1,43 @@
+package heatmap
+
+import (
+	"image"
+	"image/color"
+)
+
+// A ColorScheme maps a normalized value t, in [0,1], to a color. It's a
+// drop-in alternative to a fixed []color.Color stop list for callers
+// who want to plug in an arbitrary palette function, such as one of the
+// perceptually-uniform gradients in the schemes package.
+type ColorScheme interface {
+	At(t float64) color.Color
+}
+
+// schemeLUT samples scheme into a 256-entry []color.Color ordered the
+// way Heatmap's legacy scheme slices are: index 0 is the highest-impact
+// end (t=1) and index 255 is the lowest (t=0). This lets a ColorScheme
+// feed the same warm/warmRGBA machinery a legacy []color.Color does.
+func schemeLUT(scheme ColorScheme) []color.Color {
+	colors := make([]color.Color, 256)
+	for i := range colors {
+		t := 1.0 - float64(i)/255.0
+		colors[i] = scheme.At(t)
+	}
+	return colors
+}
+
+// HeatmapWithScheme is like Heatmap, but takes a ColorScheme instead of
+// a fixed []color.Color.
+func HeatmapWithScheme(size image.Rectangle, points []DataPoint, dotSize int, opacity uint8,
+	scheme ColorScheme, proj Projection) image.Image {
+
+	return Heatmap(size, points, dotSize, opacity, schemeLUT(scheme), proj)
+}
+
+// HeatmapKDEWithScheme is like HeatmapKDE, but takes a ColorScheme
+// instead of a fixed []color.Color.
+func HeatmapKDEWithScheme(size image.Rectangle, points []DataPoint, kernel Kernel,
+	compression Compression, opacity uint8, scheme ColorScheme, proj Projection) image.Image {
+
+	return HeatmapKDE(size, points, kernel, compression, opacity, schemeLUT(scheme), proj)
+}
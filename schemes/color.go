@@ -0,0 +1,230 @@
+package schemes
+
+import (
+	"image/color"
+	"math"
+)
+
+func rgba8(c color.Color) (r, g, b, a uint8) {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return nc.R, nc.G, nc.B, nc.A
+}
+
+func clamp8(v float64) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+func lerp8(a, b uint8, t float64) uint8 {
+	return clamp8(float64(a) + (float64(b)-float64(a))*t)
+}
+
+// lerpRGB interpolates linearly between a and b in sRGB space.
+func lerpRGB(a, b color.Color, t float64) color.Color {
+	ar, ag, ab, aa := rgba8(a)
+	br, bg, bb, ba := rgba8(b)
+	return color.NRGBA{
+		R: lerp8(ar, br, t),
+		G: lerp8(ag, bg, t),
+		B: lerp8(ab, bb, t),
+		A: lerp8(aa, ba, t),
+	}
+}
+
+// sRGB <-> linear RGB <-> CIE XYZ (D65) <-> CIE L*a*b*, following the
+// standard chain used to interpolate colors perceptually.
+
+const (
+	whiteX = 0.95047
+	whiteY = 1.0
+	whiteZ = 1.08883
+)
+
+func srgbToLinear(c uint8) float64 {
+	v := float64(c) / 255.0
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) uint8 {
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return clamp8(v * 255)
+}
+
+func rgbToXYZ(r, g, b uint8) (x, y, z float64) {
+	rl, gl, bl := srgbToLinear(r), srgbToLinear(g), srgbToLinear(b)
+	x = rl*0.4124564 + gl*0.3575761 + bl*0.1804375
+	y = rl*0.2126729 + gl*0.7151522 + bl*0.0721750
+	z = rl*0.0193339 + gl*0.1191920 + bl*0.9503041
+	return
+}
+
+func xyzToRGB(x, y, z float64) (r, g, b uint8) {
+	rl := x*3.2404542 + y*-1.5371385 + z*-0.4985314
+	gl := x*-0.9692660 + y*1.8760108 + z*0.0415560
+	bl := x*0.0556434 + y*-0.2040259 + z*1.0572252
+	return linearToSRGB(rl), linearToSRGB(gl), linearToSRGB(bl)
+}
+
+const labDelta = 6.0 / 29.0
+
+func labF(t float64) float64 {
+	if t > labDelta*labDelta*labDelta {
+		return math.Cbrt(t)
+	}
+	return t/(3*labDelta*labDelta) + 4.0/29.0
+}
+
+func labFInv(t float64) float64 {
+	if t > labDelta {
+		return t * t * t
+	}
+	return 3 * labDelta * labDelta * (t - 4.0/29.0)
+}
+
+func xyzToLab(x, y, z float64) (l, a, b float64) {
+	fx, fy, fz := labF(x/whiteX), labF(y/whiteY), labF(z/whiteZ)
+	l = 116*fy - 16
+	a = 500 * (fx - fy)
+	b = 200 * (fy - fz)
+	return
+}
+
+func labToXYZ(l, a, b float64) (x, y, z float64) {
+	fy := (l + 16) / 116
+	x = whiteX * labFInv(fy+a/500)
+	y = whiteY * labFInv(fy)
+	z = whiteZ * labFInv(fy-b/200)
+	return
+}
+
+func colorToLab(c color.Color) (l, a, b float64, alpha uint8) {
+	r, g, bl, a8 := rgba8(c)
+	x, y, z := rgbToXYZ(r, g, bl)
+	l, a, b = xyzToLab(x, y, z)
+	return l, a, b, a8
+}
+
+func labToColor(l, a, b float64, alpha uint8) color.Color {
+	x, y, z := labToXYZ(l, a, b)
+	r, g, b8 := xyzToRGB(x, y, z)
+	return color.NRGBA{R: r, G: g, B: b8, A: alpha}
+}
+
+// lerpLAB interpolates a and b by converting each to CIE L*a*b*,
+// lerping L*, a* and b*, then converting back to sRGB.
+func lerpLAB(a, b color.Color, t float64) color.Color {
+	al, aa, ab, aAlpha := colorToLab(a)
+	bl, ba, bb, bAlpha := colorToLab(b)
+	return labToColor(
+		al+(bl-al)*t,
+		aa+(ba-aa)*t,
+		ab+(bb-ab)*t,
+		lerp8(aAlpha, bAlpha, t),
+	)
+}
+
+func rgbToHSL(r, g, b uint8) (h, s, l float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+
+	switch max {
+	case rf:
+		h = (gf - bf) / d
+		if gf < bf {
+			h += 6
+		}
+	case gf:
+		h = (bf-rf)/d + 2
+	default:
+		h = (rf-gf)/d + 4
+	}
+	h *= 60
+	return
+}
+
+func hueToRGB(p, q, t float64) float64 {
+	if t < 0 {
+		t++
+	}
+	if t > 1 {
+		t--
+	}
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 1.0/2:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	default:
+		return p
+	}
+}
+
+func hslToRGB(h, s, l float64) (r, g, b uint8) {
+	if s == 0 {
+		v := clamp8(l * 255)
+		return v, v, v
+	}
+
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	hk := h / 360
+
+	return clamp8(hueToRGB(p, q, hk+1.0/3) * 255),
+		clamp8(hueToRGB(p, q, hk) * 255),
+		clamp8(hueToRGB(p, q, hk-1.0/3) * 255)
+}
+
+// lerpHSL interpolates a and b by converting each to HSL, lerping hue
+// along its shorter arc and lerping saturation/lightness linearly, then
+// converting back to sRGB.
+func lerpHSL(a, b color.Color, t float64) color.Color {
+	ar, ag, ab, aAlpha := rgba8(a)
+	br, bg, bb, bAlpha := rgba8(b)
+	ah, as, al := rgbToHSL(ar, ag, ab)
+	bh, bs, bl := rgbToHSL(br, bg, bb)
+
+	dh := bh - ah
+	switch {
+	case dh > 180:
+		dh -= 360
+	case dh < -180:
+		dh += 360
+	}
+	h := math.Mod(ah+dh*t+360, 360)
+	s := as + (bs-as)*t
+	l := al + (bl-al)*t
+
+	r, g, bch := hslToRGB(h, s, l)
+	return color.NRGBA{R: r, G: g, B: bch, A: lerp8(aAlpha, bAlpha, t)}
+}
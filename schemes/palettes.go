@@ -0,0 +1,51 @@
+package schemes
+
+import (
+	"fmt"
+	"image/color"
+)
+
+func hex(s string) color.Color {
+	var r, g, b uint8
+	fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b)
+	return color.NRGBA{R: r, G: g, B: b, A: 255}
+}
+
+// buildPalette interpolates the given hex anchor colors, evenly spaced
+// along [0,1], in LAB space and samples the result into a 256-entry
+// color.Palette. Heatmap and HeatmapKDE index a []color.Color with
+// (1-percent), so index 0 must be the hottest end of the gradient (the
+// last anchor) and index 255 the coldest (the first anchor) for the
+// palette to render hot spots hot when passed in directly as the
+// legacy []color.Color scheme.
+func buildPalette(anchors ...string) color.Palette {
+	stops := make([]Stop, len(anchors))
+	for i, a := range anchors {
+		stops[i] = Stop{Pos: float64(i) / float64(len(anchors)-1), Color: hex(a)}
+	}
+	g := LinearLABScheme(stops...)
+
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		pal[i] = g.At(1 - float64(i)/255.0)
+	}
+	return pal
+}
+
+// Viridis is a perceptually-uniform, colorblind-friendly palette
+// popularized by matplotlib, built by interpolating its published
+// anchor colors in LAB space.
+var Viridis = buildPalette("#440154", "#3b528b", "#21908d", "#5dc963", "#fde725")
+
+// Magma approximates matplotlib's magma palette.
+var Magma = buildPalette("#000004", "#3b0f70", "#8c2981", "#de4968", "#fcfdbf")
+
+// Inferno approximates matplotlib's inferno palette.
+var Inferno = buildPalette("#000004", "#420a68", "#932667", "#dd513a", "#fcffa4")
+
+// Plasma approximates matplotlib's plasma palette.
+var Plasma = buildPalette("#0d0887", "#6a00a8", "#b12a90", "#e16462", "#f0f921")
+
+// Turbo approximates Google's turbo palette, an improved rainbow map
+// designed to be more perceptually ordered than the classic jet map.
+var Turbo = buildPalette("#30123b", "#4662d7", "#36aaf9", "#1ae4b6", "#c8ef34", "#7a0403")
@@ -0,0 +1,131 @@
+// Package schemes provides color gradients and palettes for heatmap
+// overlays.
+package schemes
+
+import (
+	"image"
+	"image/color"
+)
+
+// A SchemeRange interpolates linearly in sRGB from From to To over
+// Steps discrete steps.
+type SchemeRange struct {
+	From, To color.Color
+	Steps    int
+}
+
+func (r SchemeRange) color(i int) color.Color {
+	if r.Steps <= 1 {
+		return r.To
+	}
+	return lerpRGB(r.From, r.To, float64(i)/float64(r.Steps-1))
+}
+
+// A SchemeSpec is a sequence of SchemeRanges laid end to end. It
+// implements image.Image so a spec can be previewed directly (e.g. with
+// png.Encode) as a single row as wide as its total Steps.
+type SchemeSpec []SchemeRange
+
+func (s SchemeSpec) total() int {
+	n := 0
+	for _, r := range s {
+		n += r.Steps
+	}
+	return n
+}
+
+// ColorModel implements image.Image.
+func (s SchemeSpec) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+// Bounds implements image.Image.
+func (s SchemeSpec) Bounds() image.Rectangle {
+	return image.Rect(0, 0, s.total(), 1)
+}
+
+// At implements image.Image.
+func (s SchemeSpec) At(x, y int) color.Color {
+	for _, r := range s {
+		if x < r.Steps {
+			return r.color(x)
+		}
+		x -= r.Steps
+	}
+	return color.Transparent
+}
+
+// Colors flattens a SchemeSpec into the []color.Color slice Heatmap
+// expects, one entry per step.
+func (s SchemeSpec) Colors() []color.Color {
+	colors := make([]color.Color, 0, s.total())
+	for _, r := range s {
+		for i := 0; i < r.Steps; i++ {
+			colors = append(colors, r.color(i))
+		}
+	}
+	return colors
+}
+
+// A Stop anchors a color at position Pos, in [0,1], along a Gradient.
+type Stop struct {
+	Pos   float64
+	Color color.Color
+}
+
+type lerpFunc func(a, b color.Color, t float64) color.Color
+
+// A Gradient is a heatmap.ColorScheme built from a sequence of Stops,
+// interpolated between neighboring stops by a color-space-specific
+// lerp. Build one with LinearRGBScheme, LinearLABScheme or
+// LinearHSLScheme rather than constructing it directly.
+type Gradient struct {
+	stops []Stop
+	lerp  lerpFunc
+}
+
+// At implements heatmap.ColorScheme.
+func (g Gradient) At(t float64) color.Color {
+	stops := g.stops
+	first, last := stops[0], stops[len(stops)-1]
+	if t <= first.Pos {
+		return first.Color
+	}
+	if t >= last.Pos {
+		return last.Color
+	}
+
+	for i := 0; i < len(stops)-1; i++ {
+		a, b := stops[i], stops[i+1]
+		if t >= a.Pos && t <= b.Pos {
+			span := b.Pos - a.Pos
+			if span == 0 {
+				return b.Color
+			}
+			return g.lerp(a.Color, b.Color, (t-a.Pos)/span)
+		}
+	}
+
+	return last.Color
+}
+
+// LinearRGBScheme builds a Gradient that interpolates between stops in
+// sRGB space. It's cheap but, like SchemeRange, produces muddy grey
+// midpoints between hue-distant stops.
+func LinearRGBScheme(stops ...Stop) Gradient {
+	return Gradient{stops: stops, lerp: lerpRGB}
+}
+
+// LinearLABScheme builds a Gradient that interpolates between stops in
+// CIE L*a*b* space, which is perceptually closer to uniform than RGB
+// and avoids its grey-midpoint problem.
+func LinearLABScheme(stops ...Stop) Gradient {
+	return Gradient{stops: stops, lerp: lerpLAB}
+}
+
+// LinearHSLScheme builds a Gradient that interpolates between stops in
+// HSL space, taking the shorter way around the hue wheel. It suits
+// gradients meant to sweep through hues rather than through grey.
+func LinearHSLScheme(stops ...Stop) Gradient {
+	return Gradient{stops: stops, lerp: lerpHSL}
+}
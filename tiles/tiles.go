@@ -0,0 +1,186 @@
+// Package tiles renders a heatmap as a pyramid of 256x256 web-mercator
+// tiles addressed by (z, x, y), for use in slippy maps.
+package tiles
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+
+	heatmap "github.com/dustin/go-heatmap"
+)
+
+// tileSize is the standard slippy-map tile edge length.
+const tileSize = 256
+
+// A GeoPoint is a sample in latitude/longitude, optionally weighted the
+// same way heatmap.WeightedDataPoint is. A zero W is treated as weight 1.
+type GeoPoint struct {
+	Lat, Lon float64
+	W        float64
+}
+
+// Weight returns the point's weight, defaulting to 1 when W is unset.
+func (g GeoPoint) Weight() float64 {
+	if g.W == 0 {
+		return 1
+	}
+	return g.W
+}
+
+// A TileWriter receives rendered tiles as Render produces them.
+type TileWriter interface {
+	WriteTile(z, x, y int, img image.Image) error
+}
+
+// FSWriter writes tiles as PNGs under Dir, following the {z}/{x}/{y}.png
+// layout most slippy map clients expect.
+type FSWriter struct {
+	Dir string
+}
+
+// WriteTile implements TileWriter.
+func (w FSWriter) WriteTile(z, x, y int, img image.Image) error {
+	dir := filepath.Join(w.Dir, fmt.Sprint(z), fmt.Sprint(x))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%d.png", y)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+// MemWriter collects rendered tiles in memory, keyed by (z, x, y). It's
+// useful for tests and for serving tiles without touching disk.
+type MemWriter struct {
+	Tiles map[[3]int]image.Image
+}
+
+// NewMemWriter builds an empty MemWriter.
+func NewMemWriter() *MemWriter {
+	return &MemWriter{Tiles: map[[3]int]image.Image{}}
+}
+
+// WriteTile implements TileWriter.
+func (w *MemWriter) WriteTile(z, x, y int, img image.Image) error {
+	w.Tiles[[3]int{z, x, y}] = img
+	return nil
+}
+
+// Render rasterizes points into a pyramid of tiles from minZoom to
+// maxZoom inclusive, writing each produced tile through writer.
+//
+// Points are projected to web-mercator pixel coordinates once per zoom
+// level, then bucketed into every tile their kernel's radius can reach,
+// so a point near a seam still contributes to its neighboring tiles.
+// Density for each tile is accumulated once with heatmap.Accumulate.
+// All of a zoom level's tiles are compressed with heatmap.CompressDensity
+// and colorized against one shared maximum with heatmap.ColorizeMax,
+// rather than each against its own local maximum, so the same density
+// maps to the same color on both sides of a tile seam.
+func Render(points []GeoPoint, minZoom, maxZoom int, kernel heatmap.Kernel,
+	compression heatmap.Compression, opacity uint8, scheme []color.Color, writer TileWriter) error {
+
+	bounds := image.Rect(0, 0, tileSize, tileSize)
+
+	for z := minZoom; z <= maxZoom; z++ {
+		tilePointsByKey := bucket(points, z, kernel.Radius())
+
+		compressedByKey := make(map[[3]int][]float32, len(tilePointsByKey))
+		var maxDensity float32
+		for key, tilePoints := range tilePointsByKey {
+			density := heatmap.Accumulate(bounds, tilePoints, kernel)
+			compressed, max := heatmap.CompressDensity(density, compression)
+			compressedByKey[key] = compressed
+			if max > maxDensity {
+				maxDensity = max
+			}
+		}
+
+		for key, compressed := range compressedByKey {
+			img := heatmap.ColorizeMax(compressed, bounds, maxDensity, opacity, scheme)
+			if err := writer.WriteTile(key[0], key[1], key[2], img); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// maxMercatorLat is the latitude beyond which web-mercator's y
+// coordinate diverges to infinity; standard slippy-map implementations
+// clamp to it rather than project it.
+const maxMercatorLat = 85.05112878
+
+// latLonToPixel projects a lat/lon to global pixel coordinates at zoom
+// z, using the standard web-mercator (EPSG:3857) convention. lat is
+// clamped to [-maxMercatorLat, maxMercatorLat] first.
+func latLonToPixel(lat, lon float64, z int) (x, y float64) {
+	switch {
+	case lat > maxMercatorLat:
+		lat = maxMercatorLat
+	case lat < -maxMercatorLat:
+		lat = -maxMercatorLat
+	}
+
+	scale := float64(uint(tileSize) << uint(z))
+	x = (lon + 180) / 360 * scale
+	sinLat := math.Sin(lat * math.Pi / 180)
+	y = (0.5 - math.Log((1+sinLat)/(1-sinLat))/(4*math.Pi)) * scale
+	return
+}
+
+// tilePoint is a heatmap.WeightedDataPoint already in a tile's local
+// pixel coordinates.
+type tilePoint struct {
+	x, y   float64
+	weight float64
+}
+
+func (p tilePoint) X() float64      { return p.x }
+func (p tilePoint) Y() float64      { return p.y }
+func (p tilePoint) Weight() float64 { return p.weight }
+
+// bucket projects points to zoom z and assigns each to every tile its
+// kernel radius overlaps, translated into that tile's local pixel
+// coordinates.
+func bucket(points []GeoPoint, z, radius int) map[[3]int][]heatmap.DataPoint {
+	maxIndex := (1 << uint(z)) - 1
+	tiles := map[[3]int][]heatmap.DataPoint{}
+
+	for _, p := range points {
+		gx, gy := latLonToPixel(p.Lat, p.Lon, z)
+
+		minTX := int(math.Floor((gx - float64(radius)) / tileSize))
+		maxTX := int(math.Floor((gx + float64(radius)) / tileSize))
+		minTY := int(math.Floor((gy - float64(radius)) / tileSize))
+		maxTY := int(math.Floor((gy + float64(radius)) / tileSize))
+
+		for tx := minTX; tx <= maxTX; tx++ {
+			if tx < 0 || tx > maxIndex {
+				continue
+			}
+			for ty := minTY; ty <= maxTY; ty++ {
+				if ty < 0 || ty > maxIndex {
+					continue
+				}
+				key := [3]int{z, tx, ty}
+				tiles[key] = append(tiles[key], tilePoint{
+					x:      gx - float64(tx*tileSize),
+					y:      gy - float64(ty*tileSize),
+					weight: p.Weight(),
+				})
+			}
+		}
+	}
+
+	return tiles
+}
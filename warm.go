@@ -0,0 +1,124 @@
+package heatmap
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// warm transplants pixels from in (whose alpha channel encodes impact)
+// to out, pulling the replacement color from colors and scaling its
+// alpha by opacity. in and out are always *image.RGBA in practice, so
+// that case is fast-pathed below; the draw.Image path remains as a
+// fallback for callers that pass something else in.
+func warm(out, in draw.Image, opacity uint8, colors []color.Color) {
+	if inRGBA, ok := in.(*image.RGBA); ok {
+		if outRGBA, ok := out.(*image.RGBA); ok {
+			warmRGBA(outRGBA, inRGBA, opacity, colors)
+			return
+		}
+	}
+	warmSlow(out, in, opacity, colors)
+}
+
+// alphaLUT precomputes the premultiplied RGBA bytes warmRGBA writes for
+// every possible input alpha byte, turning the per-pixel work into a
+// byte load followed by four byte stores.
+func alphaLUT(opacity uint8, colors []color.Color) [256][4]uint8 {
+	var lut [256][4]uint8
+	collen := float64(len(colors))
+
+	for a := 0; a < 256; a++ {
+		percent := float64(a) / 255.0
+
+		var r, g, b, outAlpha uint8
+		if percent == 0 {
+			r, g, b, outAlpha = 0, 0, 0, 50
+		} else {
+			template := colors[int((collen-1)*(1.0-percent))]
+			tr, tg, tb, ta := template.RGBA()
+			ta /= 256
+			outAlpha = uint8(float64(ta) * (float64(opacity) / 256.0))
+			r, g, b = uint8(tr/256), uint8(tg/256), uint8(tb/256)
+		}
+
+		lut[a] = [4]uint8{
+			uint8(uint16(r) * uint16(outAlpha) / 255),
+			uint8(uint16(g) * uint16(outAlpha) / 255),
+			uint8(uint16(b) * uint16(outAlpha) / 255),
+			outAlpha,
+		}
+	}
+
+	return lut
+}
+
+// warmRGBA is the fast path: it reads the input alpha byte directly out
+// of in.Pix and writes the precomputed, already-premultiplied output
+// bytes directly into out.Pix, avoiding the color.Color interface and
+// its RGBA<->NRGBA conversions entirely.
+func warmRGBA(out, in *image.RGBA, opacity uint8, colors []color.Color) {
+	lut := alphaLUT(opacity, colors)
+	bounds := in.Bounds()
+
+	wg := sync.WaitGroup{}
+	wg.Add(bounds.Dx())
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		go func(x int) {
+			defer wg.Done()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				inOff := in.PixOffset(x, y)
+				alpha := in.Pix[inOff+3]
+				px := lut[alpha]
+
+				outOff := out.PixOffset(x, y)
+				out.Pix[outOff+0] = px[0]
+				out.Pix[outOff+1] = px[1]
+				out.Pix[outOff+2] = px[2]
+				out.Pix[outOff+3] = px[3]
+			}
+		}(x)
+	}
+	wg.Wait()
+}
+
+// warmSlow is the original color.Color-based implementation, kept as a
+// fallback for draw.Image inputs that aren't *image.RGBA.
+func warmSlow(out, in draw.Image, opacity uint8, colors []color.Color) {
+	bounds := in.Bounds()
+	collen := float64(len(colors))
+	wg := sync.WaitGroup{}
+	wg.Add(bounds.Dx())
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		go func(x int) {
+			defer wg.Done()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				col := in.At(x, y)
+				_, _, _, alpha := col.RGBA()
+				percent := float64(alpha) / float64(0xffff)
+				var outcol color.Color
+				if percent == 0 {
+					outcol = color.NRGBA{
+						uint8(0),
+						uint8(0),
+						uint8(0),
+						uint8(50)}
+				} else {
+					template := colors[int((collen-1)*(1.0-percent))]
+					tr, tg, tb, ta := template.RGBA()
+					ta /= 256
+					outalpha := uint8(float64(ta) *
+						(float64(opacity) / 256.0))
+					outcol = color.NRGBA{
+						uint8(tr / 256),
+						uint8(tg / 256),
+						uint8(tb / 256),
+						uint8(outalpha)}
+				}
+				out.Set(x, y, outcol)
+			}
+		}(x)
+	}
+	wg.Wait()
+}